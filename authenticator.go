@@ -0,0 +1,37 @@
+package gowfs
+
+import "net/http"
+
+// Authenticator is implemented by pluggable auth strategies that need more
+// than the default user.name query-string convention, e.g. SPNEGO/Kerberos
+// against a secured WebHDFS endpoint. See the kerberos subpackage for the
+// reference implementation.
+type Authenticator interface {
+	// Authenticate decorates req with whatever credentials the strategy
+	// requires (typically an Authorization header) before it is sent.
+	Authenticate(req *http.Request) error
+
+	// Renegotiate is invoked after a 401 response. It gives the
+	// authenticator a chance to refresh its ticket/token; it returns true
+	// if the caller should retry the request once more.
+	Renegotiate(req *http.Request, rsp *http.Response) (bool, error)
+}
+
+// DelegationTokenSource is implemented by authenticators that can exchange
+// their credentials for an HDFS delegation token, so the FileSystem can
+// avoid renegotiating SPNEGO on every request.
+type DelegationTokenSource interface {
+	Authenticator
+
+	// FetchDelegationToken requests a new delegation token, typically via
+	// OP_GETDELEGATIONTOKEN, using the supplied client/namenode.
+	FetchDelegationToken(fs *FileSystem) (string, error)
+
+	// RenewDelegationToken renews an existing token via
+	// OP_RENEWDELEGATIONTOKEN.
+	RenewDelegationToken(fs *FileSystem, token string) error
+
+	// CancelDelegationToken releases a token via
+	// OP_CANCELDELEGATIONTOKEN.
+	CancelDelegationToken(fs *FileSystem, token string) error
+}
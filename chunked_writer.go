@@ -0,0 +1,258 @@
+package gowfs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+)
+
+const defaultChunkSize = 4 << 20 // 4MiB, comfortably under typical WebHDFS block size
+
+// ChunkedWriter is an io.WriteCloser that fronts OP_CREATE followed by
+// repeated OP_APPEND calls, so a caller can stream an upload of arbitrary
+// size without buffering it all in memory. When built with a checkpoint
+// file, an interrupted upload can be resumed from the last committed
+// offset instead of restarting from scratch.
+//
+// ChunkedWriter has no concurrency option: HDFS APPEND is ordered per file
+// (a single writer lease), so chunks must be sent one at a time regardless
+// of how many goroutines a caller throws at it. Concurrent transfer is what
+// ParallelDownloader is for, on the read side, where ranged OP_OPEN calls
+// have no such ordering constraint.
+type ChunkedWriter struct {
+	fs   *FileSystem
+	path *Path
+	ctx  context.Context
+
+	chunkSize      int
+	checkpointPath string
+
+	buf    bytes.Buffer
+	offset int64
+	closed bool
+}
+
+// ChunkedWriterOption configures a ChunkedWriter returned by
+// FileSystem.NewChunkedWriter.
+type ChunkedWriterOption func(*ChunkedWriter)
+
+// WithChunkSize sets the size, in bytes, flushed per OP_APPEND call.
+func WithChunkSize(n int) ChunkedWriterOption {
+	return func(w *ChunkedWriter) { w.chunkSize = n }
+}
+
+// WithCheckpointFile persists the committed offset to path after every
+// successful chunk, so a later NewChunkedWriter call for the same path can
+// resume instead of starting over. The checkpoint file is removed on a
+// clean Close.
+func WithCheckpointFile(path string) ChunkedWriterOption {
+	return func(w *ChunkedWriter) { w.checkpointPath = path }
+}
+
+// WithContext threads ctx through every OP_CREATE/OP_APPEND/OP_GETFILECHECKSUM
+// call the writer makes, so cancelling ctx (or its deadline expiring) aborts
+// an in-flight Write/Close instead of leaving the caller blocked for the
+// whole upload. Defaults to context.Background().
+func WithContext(ctx context.Context) ChunkedWriterOption {
+	return func(w *ChunkedWriter) { w.ctx = ctx }
+}
+
+type writerCheckpoint struct {
+	Path     string `json:"path"`
+	Offset   int64  `json:"offset"`
+	Checksum string `json:"checksum"`
+}
+
+// NewChunkedWriter prepares a streaming upload to p. If a checkpoint file
+// from a prior, interrupted upload to the same path is found, it is trusted
+// only once the namenode's current OP_GETFILECHECKSUM for p matches the
+// checksum recorded alongside the checkpoint's offset (via verifyChecksum);
+// a mismatch means the file changed since the checkpoint was written (another
+// writer touched it, or a prior chunk never actually committed), so resuming
+// blind would silently corrupt the upload. Otherwise OP_CREATE
+// truncates/creates p.
+func (fs *FileSystem) NewChunkedWriter(p *Path, opts ...ChunkedWriterOption) (*ChunkedWriter, error) {
+	w := &ChunkedWriter{fs: fs, path: p, ctx: context.Background(), chunkSize: defaultChunkSize}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	if w.checkpointPath != "" {
+		if cp, ok, err := loadWriterCheckpoint(w.checkpointPath); err != nil {
+			return nil, err
+		} else if ok && cp.Path == p.Name {
+			if err := fs.verifyChecksum(w.ctx, p, cp.Checksum); err != nil {
+				return nil, fmt.Errorf("gowfs: resuming upload to %s: %w", p.Name, err)
+			}
+			w.offset = cp.Offset
+			return w, nil
+		}
+	}
+
+	u, err := fs.OpUrl(OP_CREATE, p, map[string]string{"overwrite": "true"})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(w.ctx, http.MethodPut, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	// Unlike most ops, a successful CREATE answers 201 Created rather than
+	// 200 OK, so this goes through DoAuthenticated and checks status itself
+	// instead of RequestWithContext, which treats anything but 200 as an
+	// error.
+	rsp, err := fs.DoAuthenticated(req)
+	if err != nil {
+		return nil, fmt.Errorf("gowfs: creating %s: %w", p.Name, err)
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusCreated && rsp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("gowfs: creating %s: %w", p.Name, ErrBadStatusCode)
+	}
+
+	return w, nil
+}
+
+// Write buffers b and flushes full chunks to the namenode via OP_APPEND.
+func (w *ChunkedWriter) Write(b []byte) (int, error) {
+	if w.closed {
+		return 0, fmt.Errorf("gowfs: write to closed ChunkedWriter for %s", w.path.Name)
+	}
+
+	n, _ := w.buf.Write(b)
+	for w.buf.Len() >= w.chunkSize {
+		if err := w.flush(w.buf.Next(w.chunkSize)); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// Close flushes any buffered remainder and, if a checkpoint file was
+// configured, removes it on success.
+func (w *ChunkedWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	if w.buf.Len() > 0 {
+		if err := w.flush(w.buf.Bytes()); err != nil {
+			return err
+		}
+		w.buf.Reset()
+	}
+
+	if w.checkpointPath != "" {
+		if err := os.Remove(w.checkpointPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *ChunkedWriter) flush(chunk []byte) error {
+	delays := w.fs.Config.Retries()
+
+	var lastErr error
+	for attempt := 0; attempt <= len(delays); attempt++ {
+		if attempt > 0 {
+			select {
+			case <-w.ctx.Done():
+				return w.ctx.Err()
+			case <-time.After(delays[attempt-1]):
+			}
+		}
+
+		u, err := w.fs.OpUrl(OP_APPEND, w.path, nil)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(w.ctx, http.MethodPost, u.String(), bytes.NewReader(chunk))
+		if err != nil {
+			return err
+		}
+		req.ContentLength = int64(len(chunk))
+
+		if _, lastErr = w.fs.RequestWithContext(w.ctx, req); lastErr == nil {
+			w.offset += int64(len(chunk))
+			return w.checkpoint()
+		}
+	}
+	return fmt.Errorf("gowfs: appending to %s: %w", w.path.Name, lastErr)
+}
+
+// checkpoint persists the committed offset alongside the namenode's current
+// checksum for w.path, so a later resume can tell the file wasn't touched by
+// anyone else in the meantime before trusting that offset.
+func (w *ChunkedWriter) checkpoint() error {
+	if w.checkpointPath == "" {
+		return nil
+	}
+	checksum, err := w.fs.fetchChecksum(w.ctx, w.path)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(writerCheckpoint{Path: w.path.Name, Offset: w.offset, Checksum: checksum})
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(w.checkpointPath, data, 0600)
+}
+
+func loadWriterCheckpoint(path string) (writerCheckpoint, bool, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return writerCheckpoint{}, false, nil
+	}
+	if err != nil {
+		return writerCheckpoint{}, false, err
+	}
+	var cp writerCheckpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return writerCheckpoint{}, false, err
+	}
+	return cp, true, nil
+}
+
+// fetchChecksum retrieves the namenode's current OP_GETFILECHECKSUM digest
+// for p.
+func (fs *FileSystem) fetchChecksum(ctx context.Context, p *Path) (string, error) {
+	u, err := fs.OpUrl(OP_GETFILECHECKSUM, p, nil)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	data, err := fs.RequestWithContext(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return data.FileChecksum.Bytes, nil
+}
+
+// verifyChecksum compares the namenode's OP_GETFILECHECKSUM for p against
+// want, returning an error on mismatch. Callers resuming a transfer use this
+// to confirm the bytes already committed match what they expect to have
+// written before continuing past them. An empty want always passes, since
+// there's nothing yet to have drifted from.
+func (fs *FileSystem) verifyChecksum(ctx context.Context, p *Path, want string) error {
+	got, err := fs.fetchChecksum(ctx, p)
+	if err != nil {
+		return err
+	}
+	if want != "" && got != want {
+		return fmt.Errorf("gowfs: checksum mismatch for %s: want %s, got %s", p.Name, want, got)
+	}
+	return nil
+}
+
+var _ io.WriteCloser = (*ChunkedWriter)(nil)
@@ -0,0 +1,111 @@
+package gowfs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockChunkedWriterServer is a minimal in-memory WebHDFS namenode covering
+// just the ops ChunkedWriter issues: OP_CREATE (201 Created, truncates),
+// OP_APPEND (200 OK, appends), and OP_GETFILECHECKSUM (a checksum that
+// changes with content, so a resumed writer can detect drift).
+type mockChunkedWriterServer struct {
+	mu      sync.Mutex
+	content []byte
+	appends int
+}
+
+func (m *mockChunkedWriterServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch r.URL.Query().Get("op") {
+	case OP_CREATE:
+		m.content = nil
+		w.WriteHeader(http.StatusCreated)
+
+	case OP_APPEND:
+		body, _ := ioutil.ReadAll(r.Body)
+		m.content = append(m.content, body...)
+		m.appends++
+		w.WriteHeader(http.StatusOK)
+
+	case OP_GETFILECHECKSUM:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"FileChecksum": map[string]interface{}{"bytes": fmt.Sprintf("%d", len(m.content))},
+		})
+
+	default:
+		http.Error(w, "unsupported op", http.StatusNotImplemented)
+	}
+}
+
+// TestChunkedWriterCreateAccepts201 confirms NewChunkedWriter treats
+// OP_CREATE's real-world 201 Created response as success rather than
+// tripping the generic bad-status-code error.
+func TestChunkedWriterCreateAccepts201(t *testing.T) {
+	m := &mockChunkedWriterServer{}
+	server := httptest.NewServer(m)
+	defer server.Close()
+
+	fs, err := NewFileSystem(Configuration{Addr: mustHost(t, server.URL)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := fs.NewChunkedWriter(&Path{Name: "/upload"})
+	if err != nil {
+		t.Fatalf("NewChunkedWriter: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+// TestChunkedWriterWriteAppends confirms Write buffers and flushes full
+// chunks via OP_APPEND, and Close flushes the remainder.
+func TestChunkedWriterWriteAppends(t *testing.T) {
+	m := &mockChunkedWriterServer{}
+	server := httptest.NewServer(m)
+	defer server.Close()
+
+	fs, err := NewFileSystem(Configuration{
+		Addr:    mustHost(t, server.URL),
+		Retries: func() []time.Duration { return nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := fs.NewChunkedWriter(&Path{Name: "/upload"}, WithChunkSize(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload := []byte("hello world")
+	if _, err := w.Write(payload); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	m.mu.Lock()
+	got := string(m.content)
+	appends := m.appends
+	m.mu.Unlock()
+
+	if got != string(payload) {
+		t.Fatalf("server received %q, want %q", got, payload)
+	}
+	if appends < 2 {
+		t.Fatalf("expected Write to flush in more than one OP_APPEND call with a 4-byte chunk size, got %d", appends)
+	}
+}
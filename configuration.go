@@ -3,6 +3,7 @@ package gowfs
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"os/user"
 	"time"
@@ -11,7 +12,13 @@ import (
 const WebHdfsVer string = "/webhdfs/v1"
 
 type Configuration struct {
-	Addr                  string // host:port
+	Addr string // host:port
+
+	// Addrs, when set, lists the namenodes of an HA pair/quorum
+	// ("nn1:50070", "nn2:50070", ...). FileSystem tracks which one last
+	// answered successfully and transparently retries the next one on a
+	// StandbyException. Addr is ignored when Addrs is non-empty.
+	Addrs                 []string
 	BasePath              string // initial base path to be appended
 	User                  string // user.name to use to connect
 	Password              string
@@ -24,6 +31,50 @@ type Configuration struct {
 	UseHTTPS              bool
 	TLSClientSkipSecurity bool
 	Retries               func() []time.Duration
+
+	// Authenticator, when set, takes over request authentication instead
+	// of the default user.name query-string convention. The kerberos
+	// subpackage provides a SPNEGO/Kerberos implementation driven by the
+	// fields below.
+	Authenticator     Authenticator
+	KerberosPrincipal string // e.g. "hdfs/client@EXAMPLE.COM"
+	KeytabPath        string // path to the keytab backing KerberosPrincipal
+	KerberosConfig    string // path to krb5.conf
+	Realm             string // Kerberos realm, if not derivable from KerberosPrincipal
+
+	// HTTPClient, when set, is copied instead of building an *http.Client
+	// from the fields above. Useful for tests or callers that already
+	// manage their own client. NewFileSystem replaces the copy's
+	// CheckRedirect and Jar with its own; the caller's client is unchanged.
+	HTTPClient *http.Client
+
+	// Transport, when set, is used as the base RoundTripper instead of
+	// the internal one built from ConnectionTimeout/TLSClientSkipSecurity
+	// etc. Combine with RoundTripperMiddleware to splice in mTLS,
+	// tracing, or a reverse-proxy transport without forking gowfs.
+	Transport http.RoundTripper
+
+	// RoundTripperMiddleware, when set, wraps the base transport before
+	// it's assigned to the http.Client.
+	RoundTripperMiddleware func(http.RoundTripper) http.RoundTripper
+
+	// DataNodeRewriter rewrites the redirect Location returned by
+	// CREATE/OPEN/APPEND/... before it is followed, for clusters whose
+	// DataNodes are unreachable at the hostname/port the namenode
+	// advertises (internal DNS, wrong scheme, proxy-fronted). Applied
+	// after UseDatanodeHostname/DataNodePortMap.
+	DataNodeRewriter func(*url.URL) *url.URL
+
+	// UseDatanodeHostname, when true, leaves the DataNode's advertised
+	// hostname in the redirect Location as-is (the WebHDFS default
+	// behavior); when false, DataNodePortMap may still be applied to the
+	// advertised host. Mirrors dfs.client.use.datanode.hostname.
+	UseDatanodeHostname bool
+
+	// DataNodePortMap remaps a DataNode's advertised "host:port" (or bare
+	// host) to the address reachable from this client, e.g. when DataNodes
+	// sit behind a NodePort or reverse proxy.
+	DataNodePortMap map[string]string
 }
 
 func NewConfiguration() *Configuration {
@@ -48,11 +99,16 @@ func (conf *Configuration) GetNameNodeUrl() (*url.URL, error) {
 
 	var urlStr = fmt.Sprintf("%s://%s%s%s", protocol, conf.Addr, WebHdfsVer, conf.BasePath)
 
-	if &conf.User == nil || len(conf.User) == 0 {
-		u, _ := user.Current()
-		conf.User = u.Username
+	// Kerberos/SPNEGO and delegation-token auth carry their own
+	// credentials, so the user.name query param is neither needed nor
+	// honored by a secured namenode.
+	if conf.Authenticator == nil {
+		if &conf.User == nil || len(conf.User) == 0 {
+			u, _ := user.Current()
+			conf.User = u.Username
+		}
+		urlStr = urlStr + "?user.name=" + conf.User
 	}
-	urlStr = urlStr + "?user.name=" + conf.User
 
 	u, err := url.Parse(urlStr)
 
@@ -0,0 +1,54 @@
+package gowfs
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// rewriteDataNodeUrl applies Config.UseDatanodeHostname, Config.DataNodePortMap
+// and Config.DataNodeRewriter, in that order, to a redirect Location returned
+// by CREATE/OPEN/APPEND/GETFILECHECKSUM against a DataNode.
+func (conf Configuration) rewriteDataNodeUrl(u *url.URL) *url.URL {
+	if !conf.UseDatanodeHostname {
+		if mapped, ok := conf.DataNodePortMap[u.Host]; ok {
+			u.Host = mapped
+		} else if mapped, ok := conf.DataNodePortMap[u.Hostname()]; ok {
+			u.Host = mapped
+		}
+	}
+
+	if conf.DataNodeRewriter != nil {
+		u = conf.DataNodeRewriter(u)
+	}
+
+	return u
+}
+
+// maxRedirects bounds the DataNode redirect chain checkRedirect will follow.
+// http.Client only applies its own default cap of 10 when CheckRedirect is
+// nil, so supplying checkRedirect must reinstate an equivalent bound itself.
+const maxRedirects = 10
+
+// checkRedirect is installed as the http.Client's CheckRedirect. WebHDFS
+// answers CREATE/OPEN/APPEND/GETFILECHECKSUM with a 307 to a DataNode;
+// before following it we apply the configured rewriting hooks and, on a
+// cross-host hop, re-run the Authenticator so the DataNode gets a valid
+// Authorization header too.
+func (fs *FileSystem) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("gowfs: stopped after %d redirects", maxRedirects)
+	}
+
+	req.URL = fs.Config.rewriteDataNodeUrl(req.URL)
+
+	if len(via) > 0 && via[0].URL.Host != req.URL.Host {
+		if auth := fs.Config.Authenticator; auth != nil {
+			if err := auth.Authenticate(req); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -6,15 +6,21 @@ See https://github.com/vladimirvivien/gowfs.
 package gowfs
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
+	"sync"
+	"time"
+
+	"github.com/glutamatt/gowfs/hdfserr"
 )
 
 const (
@@ -42,48 +48,154 @@ const (
 
 var errBadStatusCode = errors.New("bad status code")
 
+// ErrBadStatusCode is the sentinel wrapped into the error returned when a
+// WebHDFS call answers with an unexpected HTTP status. It's exported so
+// packages issuing raw requests via FileSystem.Do/OpUrl (e.g. fsadapter)
+// can report failures the same way, and compare with errors.Is.
+var ErrBadStatusCode = errBadStatusCode
+
+// DecodeHdfsJsonData parses a WebHDFS JSON response body. It's exported for
+// callers that use FileSystem.Do/OpUrl directly instead of one of the
+// operation methods, such as the fsadapter subpackage.
+func DecodeHdfsJsonData(r io.Reader) (HdfsJsonData, error) {
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return HdfsJsonData{}, err
+	}
+	return makeHdfsData(body)
+}
+
 // Hack for in-lining multi-value functions
 func µ(v ...interface{}) []interface{} {
 	return v
 }
 
+// How often a delegation token is renewed, well inside HDFS's default
+// one-day renewal window.
+const delegationTokenRenewInterval = 12 * time.Hour
+
 // This type maps fields and functions to HDFS's FileSystem class.
 type FileSystem struct {
 	Config    Configuration
 	client    http.Client
 	transport *http.Transport
+
+	delegationMu     sync.RWMutex
+	delegationToken  string
+	stopDelegationFn func()
+
+	// nnMu guards activeNN, the index into Config.Addrs last known to be
+	// the active namenode of an HA pair/quorum.
+	nnMu     sync.RWMutex
+	activeNN int
 }
 
 func NewFileSystem(conf Configuration) (*FileSystem, error) {
 	fs := &FileSystem{
 		Config: conf,
 	}
-	fs.transport = &http.Transport{
-		Dial: func(netw, addr string) (net.Conn, error) {
-			c, err := net.DialTimeout(netw, addr, conf.ConnectionTimeout)
-			if err != nil {
-				return nil, err
-			}
 
-			return c, nil
-		},
-		MaxIdleConnsPerHost:   conf.MaxIdleConnsPerHost,
-		ResponseHeaderTimeout: conf.ResponseHeaderTimeout,
-		TLSClientConfig:       &tls.Config{InsecureSkipVerify: conf.TLSClientSkipSecurity},
-	}
-	fs.client = http.Client{
-		Transport: fs.transport,
+	if conf.HTTPClient != nil {
+		fs.client = *conf.HTTPClient
+	} else {
+		var rt http.RoundTripper
+		if conf.Transport != nil {
+			rt = conf.Transport
+		} else {
+			fs.transport = &http.Transport{
+				Dial: func(netw, addr string) (net.Conn, error) {
+					c, err := net.DialTimeout(netw, addr, conf.ConnectionTimeout)
+					if err != nil {
+						return nil, err
+					}
+
+					return c, nil
+				},
+				MaxIdleConnsPerHost:   conf.MaxIdleConnsPerHost,
+				ResponseHeaderTimeout: conf.ResponseHeaderTimeout,
+				TLSClientConfig:       &tls.Config{InsecureSkipVerify: conf.TLSClientSkipSecurity},
+			}
+			rt = fs.transport
+		}
+		if conf.RoundTripperMiddleware != nil {
+			rt = conf.RoundTripperMiddleware(rt)
+		}
+		fs.client = http.Client{Transport: rt}
 	}
 
+	fs.client.CheckRedirect = fs.checkRedirect
+
 	if jar, err := cookiejar.New(nil); err == nil {
 		fs.client.Jar = jar
 	} else {
 		panic(err)
 	}
 
+	if src, ok := conf.Authenticator.(DelegationTokenSource); ok {
+		token, err := src.FetchDelegationToken(fs)
+		if err != nil {
+			return nil, fmt.Errorf("kerberos: fetching delegation token: %w", err)
+		}
+		fs.setDelegationToken(token)
+		fs.startDelegationRenewal(src)
+	}
+
 	return fs, nil
 }
 
+// Close releases resources held by fs, including cancelling any delegation
+// token obtained from a DelegationTokenSource authenticator. Callers that
+// configure Kerberos/delegation-token auth should defer fs.Close().
+func (fs *FileSystem) Close() error {
+	if fs.stopDelegationFn != nil {
+		fs.stopDelegationFn()
+	}
+
+	if src, ok := fs.Config.Authenticator.(DelegationTokenSource); ok {
+		if token := fs.getDelegationToken(); token != "" {
+			return src.CancelDelegationToken(fs, token)
+		}
+	}
+	return nil
+}
+
+func (fs *FileSystem) getDelegationToken() string {
+	fs.delegationMu.RLock()
+	defer fs.delegationMu.RUnlock()
+	return fs.delegationToken
+}
+
+func (fs *FileSystem) setDelegationToken(token string) {
+	fs.delegationMu.Lock()
+	fs.delegationToken = token
+	fs.delegationMu.Unlock()
+}
+
+// startDelegationRenewal periodically renews the delegation token in the
+// background so long-lived FileSystem instances don't fall back to a fresh
+// SPNEGO negotiation on every request once the token expires.
+func (fs *FileSystem) startDelegationRenewal(src DelegationTokenSource) {
+	stop := make(chan struct{})
+	fs.stopDelegationFn = func() { close(stop) }
+
+	ticker := time.NewTicker(delegationTokenRenewInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := src.RenewDelegationToken(fs, fs.getDelegationToken()); err != nil {
+					if token, ferr := src.FetchDelegationToken(fs); ferr == nil {
+						fs.setDelegationToken(token)
+					}
+				}
+			}
+		}
+	}()
+}
+
 // Builds the canonical URL used for remote request
 func buildRequestUrl(conf Configuration, p *Path, params *map[string]string) (*url.URL, error) {
 	u, err := conf.GetNameNodeUrl()
@@ -113,6 +225,56 @@ func buildRequestUrl(conf Configuration, p *Path, params *map[string]string) (*u
 	return u, nil
 }
 
+// buildUrl is like buildRequestUrl but additionally targets the currently
+// active namenode (see Config.Addrs) and substitutes a live delegation
+// token, when fs holds one, to avoid SPNEGO renegotiation on every call.
+func (fs *FileSystem) buildUrl(p *Path, params *map[string]string) (*url.URL, error) {
+	conf := fs.Config
+	conf.Addr = fs.nameNodeAddr()
+
+	u, err := buildRequestUrl(conf, p, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if token := fs.getDelegationToken(); token != "" {
+		q := u.Query()
+		q.Set("delegation", token)
+		u.RawQuery = q.Encode()
+	}
+
+	return u, nil
+}
+
+// nameNodeAddr returns the namenode currently believed to be active. With a
+// single Config.Addr (no HA) it is returned unconditionally.
+func (fs *FileSystem) nameNodeAddr() string {
+	if len(fs.Config.Addrs) == 0 {
+		return fs.Config.Addr
+	}
+	fs.nnMu.RLock()
+	defer fs.nnMu.RUnlock()
+	return fs.Config.Addrs[fs.activeNN%len(fs.Config.Addrs)]
+}
+
+// promoteNextNameNode advances past the namenode that just returned a
+// StandbyException and returns the address of the one to try next.
+func (fs *FileSystem) promoteNextNameNode() string {
+	fs.nnMu.Lock()
+	defer fs.nnMu.Unlock()
+	if len(fs.Config.Addrs) == 0 {
+		return fs.Config.Addr
+	}
+	fs.activeNN = (fs.activeNN + 1) % len(fs.Config.Addrs)
+	return fs.Config.Addrs[fs.activeNN]
+}
+
+// isStandbyException reports whether err is the HDFS RemoteException raised
+// by a standby namenode refusing to serve a request it can't (yet) satisfy.
+func isStandbyException(err error) bool {
+	return errors.Is(err, hdfserr.ErrStandby)
+}
+
 func makeHdfsData(data []byte) (HdfsJsonData, error) {
 	if len(data) == 0 || data == nil {
 		return HdfsJsonData{}, nil
@@ -126,14 +288,17 @@ func makeHdfsData(data []byte) (HdfsJsonData, error) {
 
 	// check for remote exception
 	if jsonData.RemoteException.Exception != "" {
-		return HdfsJsonData{}, jsonData.RemoteException
+		return HdfsJsonData{}, hdfserr.Wrap(jsonData.RemoteException.JavaClassName, jsonData.RemoteException.Message)
 	}
 
 	return jsonData, nil
 
 }
 
-func responseToHdfsData(rsp *http.Response) (HdfsJsonData, error) {
+func responseToHdfsData(ctx context.Context, rsp *http.Response) (HdfsJsonData, error) {
+	if err := ctx.Err(); err != nil {
+		return HdfsJsonData{}, err
+	}
 	body, err := ioutil.ReadAll(rsp.Body)
 	if err != nil {
 		return HdfsJsonData{}, err
@@ -141,15 +306,171 @@ func responseToHdfsData(rsp *http.Response) (HdfsJsonData, error) {
 	return makeHdfsData(body)
 }
 
-func requestHdfsData(client http.Client, req http.Request) (HdfsJsonData, error) {
-	rsp, err := client.Do(&req)
+// Do sends req over fs's underlying http.Client as-is, with no
+// authentication applied, for callers (such as the kerberos subpackage's
+// delegation-token exchange) that call Config.Authenticator themselves and
+// need raw response access outside the usual HdfsJsonData envelope.
+func (fs *FileSystem) Do(req *http.Request) (*http.Response, error) {
+	return fs.client.Do(req)
+}
+
+// DoAuthenticated sends req the same way the operation methods do: unless fs
+// holds a live delegation token, it runs Config.Authenticator.Authenticate
+// first and, on a 401, gives the
+// Authenticator one chance to Renegotiate and retry. Unlike Do, callers
+// don't need to authenticate req themselves; unlike RequestWithContext, the
+// response body is returned unparsed, for callers (e.g. fsadapter's
+// streaming OP_OPEN) that need raw response access.
+func (fs *FileSystem) DoAuthenticated(req *http.Request) (*http.Response, error) {
+	return fs.doRawRequest(req.Context(), *req)
+}
+
+// OpUrl builds the canonical request URL for the given WebHDFS operation
+// against path p, targeting the currently active namenode and carrying any
+// live delegation token. It is exported so subpackages like fsadapter can
+// issue WebHDFS calls without duplicating buildUrl/buildRequestUrl.
+func (fs *FileSystem) OpUrl(op string, p *Path, params map[string]string) (*url.URL, error) {
+	all := map[string]string{"op": op}
+	for k, v := range params {
+		all[k] = v
+	}
+	return fs.buildUrl(p, &all)
+}
+
+// OpUrlNoDelegation is like OpUrl but targets the currently active namenode
+// without attaching a live delegation token. It's exported for the
+// delegation-token ops themselves (OP_GETDELEGATIONTOKEN and friends), which
+// the kerberos subpackage issues via this instead of OpUrl, since a
+// delegation param doesn't apply to obtaining/renewing/cancelling one.
+func (fs *FileSystem) OpUrlNoDelegation(op string, p *Path, params map[string]string) (*url.URL, error) {
+	conf := fs.Config
+	conf.Addr = fs.nameNodeAddr()
+
+	all := map[string]string{"op": op}
+	for k, v := range params {
+		all[k] = v
+	}
+	return buildRequestUrl(conf, p, &all)
+}
+
+// RequestHdfsData sends req with context.Background() and unmarshals the
+// WebHDFS response. It is a thin wrapper around RequestWithContext for
+// callers that don't need cancellation; prefer RequestWithContext for a
+// long-running transfer a caller may want to abort.
+func (fs *FileSystem) RequestHdfsData(req *http.Request) (HdfsJsonData, error) {
+	return fs.RequestWithContext(context.Background(), req)
+}
+
+// RequestWithContext sends req and unmarshals the WebHDFS response, failing
+// over to the next namenode (per Config.Addrs) on a StandbyException,
+// honoring ctx's cancellation/deadline throughout. It's exported so callers
+// building requests directly via OpUrl — as ChunkedWriter and
+// ParallelDownloader do — can thread a real context instead of being stuck
+// with RequestHdfsData's context.Background().
+func (fs *FileSystem) RequestWithContext(ctx context.Context, req *http.Request) (HdfsJsonData, error) {
+	return requestHdfsDataWithContext(ctx, fs, *req)
+}
+
+// requestHdfsDataWithContext sends req and unmarshals the WebHDFS response.
+// When fs is configured with multiple namenode addresses and the current one
+// answers with a StandbyException, it promotes the next address to active
+// and retries, spending attempts from the same Config.Retries policy used
+// for ordinary transient failures. Retries and the underlying HTTP round
+// trip honor ctx cancellation/deadlines.
+func requestHdfsDataWithContext(ctx context.Context, fs *FileSystem, req http.Request) (HdfsJsonData, error) {
+	delays := fs.Config.Retries()
+
+	for attempt := 0; ; attempt++ {
+		hdfsData, err := fs.doRequest(ctx, req)
+		if err == nil || len(fs.Config.Addrs) < 2 || !isStandbyException(err) || attempt >= len(delays) {
+			return hdfsData, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return hdfsData, ctx.Err()
+		case <-time.After(delays[attempt]):
+		}
+		req.URL.Host = fs.promoteNextNameNode()
+		if req.GetBody != nil {
+			body, berr := req.GetBody()
+			if berr != nil {
+				return hdfsData, berr
+			}
+			req.Body = body
+		}
+	}
+}
+
+// doRequest performs a single attempt: authenticate, send, handle one
+// 401-triggered renegotiation, and parse the response body. WebHDFS answers
+// a RemoteException (StandbyException, AccessControlException, ...)
+// alongside a non-200 status, so the body is always parsed first and
+// hdfserr.Wrap's mapping takes precedence; a non-200 response whose body
+// isn't a RemoteException of a class hdfserr recognizes (including one of
+// an unrecognized class) falls back to the coarser hdfserr.WrapStatusCode.
+func (fs *FileSystem) doRequest(ctx context.Context, req http.Request) (HdfsJsonData, error) {
+	rsp, err := fs.doRawRequest(ctx, req)
 	if err != nil {
 		return HdfsJsonData{}, err
 	}
+	defer rsp.Body.Close()
+
+	hdfsData, err := responseToHdfsData(ctx, rsp)
+
+	var remoteErr *hdfserr.RemoteException
+	if errors.As(err, &remoteErr) {
+		return HdfsJsonData{}, err
+	}
 	if rsp.StatusCode != http.StatusOK {
-		return HdfsJsonData{}, fmt.Errorf("%w : (%d) %s", errBadStatusCode, rsp.StatusCode, rsp.Status)
+		baseErr := fmt.Errorf("%w : (%d) %s", errBadStatusCode, rsp.StatusCode, rsp.Status)
+		return HdfsJsonData{}, hdfserr.WrapStatusCode(rsp.StatusCode, baseErr)
 	}
-	defer rsp.Body.Close()
-	hdfsData, err := responseToHdfsData(rsp)
 	return hdfsData, err
 }
+
+// doRawRequest is the shared core of doRequest and DoAuthenticated: it
+// authenticates, sends, and handles one 401-triggered renegotiation, but
+// returns the raw *http.Response instead of decoding it, for callers that
+// need the body unparsed (e.g. streaming OP_OPEN).
+func (fs *FileSystem) doRawRequest(ctx context.Context, req http.Request) (*http.Response, error) {
+	req2 := req.WithContext(ctx)
+
+	// A live delegation token (attached as the "delegation" query param by
+	// buildUrl) is exactly what DelegationTokenSource exists to let callers
+	// skip SPNEGO renegotiation on every request; authenticating anyway
+	// would defeat that.
+	if auth := fs.Config.Authenticator; auth != nil && fs.getDelegationToken() == "" {
+		if err := auth.Authenticate(req2); err != nil {
+			return nil, err
+		}
+	}
+
+	rsp, err := fs.client.Do(req2)
+	if err != nil {
+		return nil, err
+	}
+
+	if rsp.StatusCode == http.StatusUnauthorized && fs.Config.Authenticator != nil {
+		retry, rerr := fs.Config.Authenticator.Renegotiate(req2, rsp)
+		rsp.Body.Close()
+		if rerr != nil {
+			return nil, rerr
+		}
+		if retry {
+			if req2.GetBody != nil {
+				body, berr := req2.GetBody()
+				if berr != nil {
+					return nil, berr
+				}
+				req2.Body = body
+			}
+			rsp, err = fs.client.Do(req2)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return rsp, nil
+}
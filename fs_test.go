@@ -0,0 +1,113 @@
+package gowfs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func writeRemoteExceptionFixture(w http.ResponseWriter, status int, javaClassName, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"RemoteException": map[string]interface{}{
+			"exception":     "RemoteException",
+			"javaClassName": javaClassName,
+			"message":       message,
+		},
+	})
+}
+
+// TestHAFailoverOnStandbyException confirms that a StandbyException from the
+// currently active namenode promotes the next Config.Addrs entry and retries
+// there, rather than surfacing the StandbyException to the caller.
+func TestHAFailoverOnStandbyException(t *testing.T) {
+	standby := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeRemoteExceptionFixture(w, http.StatusForbidden, "org.apache.hadoop.ipc.StandbyException", "Operation category READ is not supported in state standby")
+	}))
+	defer standby.Close()
+
+	active := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"FileStatus": map[string]interface{}{"pathSuffix": "", "type": "DIRECTORY"},
+		})
+	}))
+	defer active.Close()
+
+	standbyAddr := mustHost(t, standby.URL)
+	activeAddr := mustHost(t, active.URL)
+
+	fs, err := NewFileSystem(Configuration{
+		Addrs:   []string{standbyAddr, activeAddr},
+		Retries: func() []time.Duration { return []time.Duration{time.Millisecond} },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := fs.OpUrl(OP_GETFILESTATUS, &Path{Name: "/"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := fs.RequestWithContext(context.Background(), req)
+	if err != nil {
+		t.Fatalf("expected failover to the active namenode to succeed, got: %v", err)
+	}
+	if data.FileStatus.Type != "DIRECTORY" {
+		t.Fatalf("got FileStatus.Type %q, want %q", data.FileStatus.Type, "DIRECTORY")
+	}
+}
+
+// TestHAFailoverExhaustsRetries confirms that a StandbyException from every
+// namenode in Config.Addrs is surfaced to the caller once retries run out,
+// instead of retrying forever.
+func TestHAFailoverExhaustsRetries(t *testing.T) {
+	standby := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeRemoteExceptionFixture(w, http.StatusForbidden, "org.apache.hadoop.ipc.StandbyException", "still standby")
+	}))
+	defer standby.Close()
+
+	addr := mustHost(t, standby.URL)
+
+	fs, err := NewFileSystem(Configuration{
+		Addrs:   []string{addr, addr},
+		Retries: func() []time.Duration { return []time.Duration{time.Millisecond, time.Millisecond} },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := fs.OpUrl(OP_GETFILESTATUS, &Path{Name: "/"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = fs.RequestWithContext(context.Background(), req)
+	if !isStandbyException(err) {
+		t.Fatalf("expected a StandbyException once retries were exhausted, got: %v", err)
+	}
+}
+
+func mustHost(t *testing.T, rawurl string) string {
+	t.Helper()
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Host
+}
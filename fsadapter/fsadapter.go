@@ -0,0 +1,329 @@
+// Package fsadapter adapts a *gowfs.FileSystem to Go's io/fs interfaces and
+// to a smaller RemoteStorageClient interface modeled after how gowfs is
+// typically wired into gateway projects (reverse proxies, template loaders,
+// http.FileServer). It lets gowfs be dropped into fs.WalkDir and friends
+// without callers manually stitching ListStatus+Open themselves.
+package fsadapter
+
+import (
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/glutamatt/gowfs"
+)
+
+// RemoteStorageClient is a narrow facade over a remote object/file store,
+// satisfied by FS, that gateway code can depend on instead of the full
+// gowfs.FileSystem surface.
+type RemoteStorageClient interface {
+	Traverse(root string, walkFn fs.WalkDirFunc) error
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte) error
+	DeleteFile(name string) error
+	ListDirectories(name string) ([]fs.DirEntry, error)
+	UpdateFileMetadata(name string, permission string) error
+}
+
+// FS adapts a *gowfs.FileSystem to fs.FS, fs.ReadDirFS, fs.StatFS,
+// fs.ReadFileFS and RemoteStorageClient. The zero value is not usable; build
+// one with New.
+type FS struct {
+	gwfs *gowfs.FileSystem
+}
+
+// New wraps gwfs for use as an fs.FS/RemoteStorageClient.
+func New(gwfs *gowfs.FileSystem) *FS {
+	return &FS{gwfs: gwfs}
+}
+
+var _ fs.FS = (*FS)(nil)
+var _ fs.ReadDirFS = (*FS)(nil)
+var _ fs.StatFS = (*FS)(nil)
+var _ fs.ReadFileFS = (*FS)(nil)
+var _ RemoteStorageClient = (*FS)(nil)
+
+func hdfsPath(name string) (*gowfs.Path, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return &gowfs.Path{Name: "/"}, nil
+	}
+	return &gowfs.Path{Name: "/" + strings.TrimPrefix(name, "/")}, nil
+}
+
+// Open implements fs.FS by issuing OP_OPEN and streaming the response body.
+func (f *FS) Open(name string) (fs.File, error) {
+	p, err := hdfsPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.statPath(p)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	if info.IsDir() {
+		entries, err := f.ReadDir(name)
+		if err != nil {
+			return nil, err
+		}
+		return &dir{info: info, entries: entries}, nil
+	}
+
+	u, err := f.gwfs.OpUrl(gowfs.OP_OPEN, p, nil)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	rsp, err := f.gwfs.DoAuthenticated(req)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	if rsp.StatusCode != http.StatusOK {
+		rsp.Body.Close()
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return &file{body: rsp.Body, info: info}, nil
+}
+
+// ReadFile implements fs.ReadFileFS.
+func (f *FS) ReadFile(name string) ([]byte, error) {
+	file, err := f.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// Stat implements fs.StatFS via OP_GETFILESTATUS.
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	p, err := hdfsPath(name)
+	if err != nil {
+		return nil, err
+	}
+	return f.statPath(p)
+}
+
+func (f *FS) statPath(p *gowfs.Path) (fs.FileInfo, error) {
+	u, err := f.gwfs.OpUrl(gowfs.OP_GETFILESTATUS, p, nil)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	rsp, err := f.gwfs.DoAuthenticated(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return nil, fs.ErrNotExist
+	}
+
+	data, err := gowfs.DecodeHdfsJsonData(rsp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return fileInfo{name: path.Base(p.Name), status: data.FileStatus}, nil
+}
+
+// ReadDir implements fs.ReadDirFS via OP_LISTSTATUS.
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	p, err := hdfsPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := f.gwfs.OpUrl(gowfs.OP_LISTSTATUS, p, nil)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	rsp, err := f.gwfs.DoAuthenticated(req)
+	if err != nil {
+		return nil, err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	data, err := gowfs.DecodeHdfsJsonData(rsp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]fs.DirEntry, 0, len(data.FileStatuses.FileStatus))
+	for _, st := range data.FileStatuses.FileStatus {
+		entries = append(entries, fileInfo{name: st.PathSuffix, status: st})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// ListDirectories implements RemoteStorageClient; it plays the role other
+// object-store adapters give ListBuckets, returning HDFS's top-level
+// directory listing for name.
+func (f *FS) ListDirectories(name string) ([]fs.DirEntry, error) {
+	return f.ReadDir(name)
+}
+
+// Traverse implements RemoteStorageClient by walking root with fs.WalkDir.
+func (f *FS) Traverse(root string, walkFn fs.WalkDirFunc) error {
+	return fs.WalkDir(f, root, walkFn)
+}
+
+// WriteFile implements RemoteStorageClient via OP_CREATE.
+func (f *FS) WriteFile(name string, data []byte) error {
+	p, err := hdfsPath(name)
+	if err != nil {
+		return err
+	}
+	u, err := f.gwfs.OpUrl(gowfs.OP_CREATE, p, map[string]string{"overwrite": "true"})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPut, u.String(), strings.NewReader(string(data)))
+	if err != nil {
+		return err
+	}
+	rsp, err := f.gwfs.DoAuthenticated(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusCreated && rsp.StatusCode != http.StatusOK {
+		return &fs.PathError{Op: "write", Path: name, Err: gowfs.ErrBadStatusCode}
+	}
+	return nil
+}
+
+// DeleteFile implements RemoteStorageClient via OP_DELETE.
+func (f *FS) DeleteFile(name string) error {
+	p, err := hdfsPath(name)
+	if err != nil {
+		return err
+	}
+	u, err := f.gwfs.OpUrl(gowfs.OP_DELETE, p, nil)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodDelete, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	rsp, err := f.gwfs.DoAuthenticated(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return &fs.PathError{Op: "delete", Path: name, Err: gowfs.ErrBadStatusCode}
+	}
+	return nil
+}
+
+// UpdateFileMetadata implements RemoteStorageClient via OP_SETPERMISSION.
+func (f *FS) UpdateFileMetadata(name string, permission string) error {
+	p, err := hdfsPath(name)
+	if err != nil {
+		return err
+	}
+	u, err := f.gwfs.OpUrl(gowfs.OP_SETPERMISSION, p, map[string]string{"permission": permission})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPut, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	rsp, err := f.gwfs.DoAuthenticated(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return &fs.PathError{Op: "chmod", Path: name, Err: gowfs.ErrBadStatusCode}
+	}
+	return nil
+}
+
+// fileInfo adapts a gowfs.FileStatus to fs.FileInfo/fs.DirEntry.
+type fileInfo struct {
+	name   string
+	status gowfs.FileStatus
+}
+
+func (i fileInfo) Name() string               { return i.name }
+func (i fileInfo) Size() int64                { return i.status.Length }
+func (i fileInfo) Mode() fs.FileMode          { return modeOf(i.status) }
+func (i fileInfo) ModTime() time.Time         { return time.UnixMilli(i.status.ModificationTime) }
+func (i fileInfo) IsDir() bool                { return i.status.Type == "DIRECTORY" }
+func (i fileInfo) Sys() interface{}           { return i.status }
+func (i fileInfo) Type() fs.FileMode          { return i.Mode().Type() }
+func (i fileInfo) Info() (fs.FileInfo, error) { return i, nil }
+
+func modeOf(st gowfs.FileStatus) fs.FileMode {
+	var mode fs.FileMode
+	if st.Type == "DIRECTORY" {
+		mode |= fs.ModeDir
+	}
+	return mode
+}
+
+// file implements fs.File for a regular HDFS file opened via OP_OPEN.
+type file struct {
+	body io.ReadCloser
+	info fs.FileInfo
+}
+
+func (f *file) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *file) Read(b []byte) (int, error) { return f.body.Read(b) }
+func (f *file) Close() error               { return f.body.Close() }
+
+// dir implements fs.File for a directory, satisfying fs.ReadDirFile.
+type dir struct {
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *dir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.Name(), Err: fs.ErrInvalid}
+}
+func (d *dir) Close() error { return nil }
+
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 {
+		rest := d.entries[d.offset:]
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if d.offset >= len(d.entries) {
+		return nil, io.EOF
+	}
+	end := d.offset + n
+	if end > len(d.entries) {
+		end = len(d.entries)
+	}
+	rest := d.entries[d.offset:end]
+	d.offset = end
+	return rest, nil
+}
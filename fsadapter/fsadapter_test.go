@@ -0,0 +1,273 @@
+package fsadapter
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+
+	"github.com/glutamatt/gowfs"
+)
+
+// mockNode is one entry in a mockWebHDFS namenode: either a regular file
+// (content set, dir false) or a directory (content nil, dir true).
+type mockNode struct {
+	content []byte
+	dir     bool
+}
+
+// mockWebHDFS is a minimal in-memory WebHDFS namenode: just enough of
+// GETFILESTATUS/LISTSTATUS/OPEN/CREATE/DELETE/SETPERMISSION, JSON-shaped the
+// way the real REST API responds, to exercise FS without a real cluster.
+type mockWebHDFS struct {
+	mu    sync.Mutex
+	nodes map[string]mockNode // hdfs path ("/", "/a", "/a/b", ...) -> node
+}
+
+func newMockWebHDFS(files map[string]string) *httptest.Server {
+	m := &mockWebHDFS{nodes: map[string]mockNode{"/": {dir: true}}}
+	for p, content := range files {
+		m.put(p, []byte(content))
+	}
+	return httptest.NewServer(m)
+}
+
+// put stores content at p, materializing any missing parent directories, the
+// way HDFS CREATE does.
+func (m *mockWebHDFS) put(p string, content []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for dir := parentOf(p); dir != ""; dir = parentOf(dir) {
+		if _, ok := m.nodes[dir]; !ok {
+			m.nodes[dir] = mockNode{dir: true}
+		}
+	}
+	m.nodes[p] = mockNode{content: content}
+}
+
+func parentOf(p string) string {
+	p = strings.TrimSuffix(p, "/")
+	if i := strings.LastIndexByte(p, '/'); i > 0 {
+		return p[:i]
+	}
+	if p != "/" && p != "" {
+		return "/"
+	}
+	return ""
+}
+
+func (m *mockWebHDFS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p := strings.TrimPrefix(r.URL.Path, gowfs.WebHdfsVer)
+	if p == "" {
+		p = "/"
+	}
+	op := r.URL.Query().Get("op")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch op {
+	case gowfs.OP_GETFILESTATUS:
+		node, ok := m.nodes[p]
+		if !ok {
+			writeRemoteException(w, http.StatusNotFound, "java.io.FileNotFoundException", p+" not found")
+			return
+		}
+		writeJSON(w, map[string]interface{}{"FileStatus": fileStatusJSON(p, node)})
+
+	case gowfs.OP_LISTSTATUS:
+		node, ok := m.nodes[p]
+		if !ok || !node.dir {
+			writeRemoteException(w, http.StatusNotFound, "java.io.FileNotFoundException", p+" not found")
+			return
+		}
+		var entries []map[string]interface{}
+		prefix := strings.TrimSuffix(p, "/") + "/"
+		for child, cn := range m.nodes {
+			if child == p || !strings.HasPrefix(child, prefix) {
+				continue
+			}
+			if strings.Contains(strings.TrimPrefix(child, prefix), "/") {
+				continue // not a direct child
+			}
+			entries = append(entries, fileStatusJSON(child, cn))
+		}
+		writeJSON(w, map[string]interface{}{"FileStatuses": map[string]interface{}{"FileStatus": entries}})
+
+	case gowfs.OP_OPEN:
+		node, ok := m.nodes[p]
+		if !ok || node.dir {
+			writeRemoteException(w, http.StatusNotFound, "java.io.FileNotFoundException", p+" not found")
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(node.content)
+
+	case gowfs.OP_CREATE:
+		body, _ := io.ReadAll(r.Body)
+		m.nodes[p] = mockNode{content: body}
+		for dir := parentOf(p); dir != ""; dir = parentOf(dir) {
+			if _, ok := m.nodes[dir]; !ok {
+				m.nodes[dir] = mockNode{dir: true}
+			}
+		}
+		w.WriteHeader(http.StatusCreated)
+
+	case gowfs.OP_DELETE:
+		delete(m.nodes, p)
+		writeJSON(w, map[string]interface{}{"boolean": true})
+
+	case gowfs.OP_SETPERMISSION:
+		if _, ok := m.nodes[p]; !ok {
+			writeRemoteException(w, http.StatusNotFound, "java.io.FileNotFoundException", p+" not found")
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+
+	default:
+		http.Error(w, "unsupported op "+op, http.StatusNotImplemented)
+	}
+}
+
+func fileStatusJSON(p string, n mockNode) map[string]interface{} {
+	typ := "FILE"
+	if n.dir {
+		typ = "DIRECTORY"
+	}
+	name := p[strings.LastIndexByte(p, '/')+1:]
+	return map[string]interface{}{
+		"pathSuffix":       name,
+		"type":             typ,
+		"length":           int64(len(n.content)),
+		"modificationTime": int64(0),
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeRemoteException(w http.ResponseWriter, status int, javaClassName, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"RemoteException": map[string]interface{}{
+			"exception":     "RemoteException",
+			"javaClassName": javaClassName,
+			"message":       message,
+		},
+	})
+}
+
+func newTestFS(t *testing.T, server *httptest.Server, auth gowfs.Authenticator) *FS {
+	t.Helper()
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gwfs, err := gowfs.NewFileSystem(gowfs.Configuration{
+		Addr:          u.Host,
+		Authenticator: auth,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(gwfs)
+}
+
+// TestFS runs the stdlib's fstest.TestFS conformance suite against FS backed
+// by a mock WebHDFS namenode.
+func TestFS(t *testing.T) {
+	server := newMockWebHDFS(map[string]string{
+		"/a":     "hello",
+		"/dir/b": "world",
+		"/dir/c": "!",
+	})
+	defer server.Close()
+
+	fsys := newTestFS(t, server, nil)
+	if err := fstest.TestFS(fsys, "a", "dir/b", "dir/c"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// spyAuthenticator counts Authenticate calls, to confirm FS routes every
+// request through Config.Authenticator instead of bypassing it.
+type spyAuthenticator struct {
+	calls int32
+}
+
+func (s *spyAuthenticator) Authenticate(req *http.Request) error {
+	atomic.AddInt32(&s.calls, 1)
+	return nil
+}
+
+func (s *spyAuthenticator) Renegotiate(req *http.Request, rsp *http.Response) (bool, error) {
+	return false, nil
+}
+
+func TestFSAuthenticatesEveryCall(t *testing.T) {
+	server := newMockWebHDFS(map[string]string{"/a": "hello"})
+	defer server.Close()
+
+	auth := &spyAuthenticator{}
+	fsys := newTestFS(t, server, auth)
+
+	if _, err := fsys.Stat("a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.ReadFile("a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.ReadDir("."); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.WriteFile("b", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.UpdateFileMetadata("b", "0644"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.DeleteFile("b"); err != nil {
+		t.Fatal(err)
+	}
+
+	// One request each for Stat, ReadDir, WriteFile, UpdateFileMetadata and
+	// DeleteFile, plus two for ReadFile (GETFILESTATUS, then OPEN).
+	const wantCalls = 7
+	if got := atomic.LoadInt32(&auth.calls); got != wantCalls {
+		t.Fatalf("expected FS to route all %d requests through Config.Authenticator, got %d Authenticate calls", wantCalls, got)
+	}
+}
+
+func TestHdfsPathRoot(t *testing.T) {
+	p, err := hdfsPath(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "/" {
+		t.Fatalf("hdfsPath(%q) = %q, want %q", ".", p.Name, "/")
+	}
+}
+
+func TestHdfsPathRootListing(t *testing.T) {
+	server := newMockWebHDFS(map[string]string{"/a": "hello"})
+	defer server.Close()
+
+	fsys := newTestFS(t, server, nil)
+	entries, err := fsys.ReadDir(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "a" {
+		t.Fatalf("ReadDir(%q) = %v, want [a]", ".", entries)
+	}
+}
@@ -0,0 +1,82 @@
+// Package hdfserr gives callers an errors.Is-friendly way to branch on the
+// kind of failure a WebHDFS call returned, instead of string-matching the
+// opaque RemoteException payload or raw HTTP status code.
+package hdfserr
+
+import (
+	"fmt"
+	"net/http"
+)
+
+var (
+	ErrFileNotFound      = fmt.Errorf("hdfserr: file not found")
+	ErrFileAlreadyExists = fmt.Errorf("hdfserr: file already exists")
+	ErrAccessControl     = fmt.Errorf("hdfserr: access control exception")
+	ErrStandby           = fmt.Errorf("hdfserr: namenode is in standby state")
+	ErrSafeMode          = fmt.Errorf("hdfserr: namenode is in safe mode")
+	ErrPathIsNotEmpty    = fmt.Errorf("hdfserr: path is not an empty directory")
+	ErrQuotaExceeded     = fmt.Errorf("hdfserr: quota exceeded")
+	ErrUnauthorized      = fmt.Errorf("hdfserr: unauthorized")
+	ErrForbidden         = fmt.Errorf("hdfserr: forbidden")
+	ErrServerError       = fmt.Errorf("hdfserr: namenode internal server error")
+)
+
+// javaClassToSentinel maps the JavaClassName of a WebHDFS RemoteException to
+// one of the sentinels above.
+var javaClassToSentinel = map[string]error{
+	"java.io.FileNotFoundException":                            ErrFileNotFound,
+	"org.apache.hadoop.fs.FileAlreadyExistsException":          ErrFileAlreadyExists,
+	"org.apache.hadoop.security.AccessControlException":        ErrAccessControl,
+	"org.apache.hadoop.ipc.StandbyException":                   ErrStandby,
+	"org.apache.hadoop.hdfs.server.namenode.SafeModeException": ErrSafeMode,
+	"org.apache.hadoop.fs.PathIsNotEmptyDirectoryException":    ErrPathIsNotEmpty,
+	"org.apache.hadoop.hdfs.protocol.DSQuotaExceededException": ErrQuotaExceeded,
+	"org.apache.hadoop.hdfs.protocol.NSQuotaExceededException": ErrQuotaExceeded,
+}
+
+// RemoteException wraps a sentinel from this package with the original
+// WebHDFS exception class and message, so errors.Is(err, hdfserr.ErrX)
+// matches while %v/Error() still surfaces the detail for logs.
+type RemoteException struct {
+	Sentinel      error
+	JavaClassName string
+	Message       string
+}
+
+func (e *RemoteException) Error() string {
+	return fmt.Sprintf("%s (%s): %s", e.Sentinel, e.JavaClassName, e.Message)
+}
+
+func (e *RemoteException) Unwrap() error { return e.Sentinel }
+
+// Wrap maps a RemoteException's JavaClassName to one of this package's
+// sentinels. Unrecognized class names fall back to a plain error carrying
+// message, so callers always get something displayable even as HDFS grows
+// new exception types.
+func Wrap(javaClassName, message string) error {
+	sentinel, ok := javaClassToSentinel[javaClassName]
+	if !ok {
+		return fmt.Errorf("hdfserr: %s: %s", javaClassName, message)
+	}
+	return &RemoteException{Sentinel: sentinel, JavaClassName: javaClassName, Message: message}
+}
+
+// WrapStatusCode maps a non-200 HTTP status to a sentinel the same way Wrap
+// does for RemoteExceptions, for responses WebHDFS answers without a JSON
+// RemoteException body (e.g. a 401 from an auth proxy in front of it).
+func WrapStatusCode(statusCode int, cause error) error {
+	var sentinel error
+	switch statusCode {
+	case http.StatusUnauthorized:
+		sentinel = ErrUnauthorized
+	case http.StatusForbidden:
+		sentinel = ErrForbidden
+	case http.StatusNotFound:
+		sentinel = ErrFileNotFound
+	case http.StatusInternalServerError:
+		sentinel = ErrServerError
+	default:
+		return cause
+	}
+	return &RemoteException{Sentinel: sentinel, JavaClassName: fmt.Sprintf("http %d", statusCode), Message: cause.Error()}
+}
@@ -0,0 +1,86 @@
+package gowfs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// mockParallelDownloaderServer is a minimal in-memory WebHDFS namenode
+// covering just the ops ParallelDownloader issues: OP_GETFILESTATUS,
+// OP_GETFILECHECKSUM, and ranged OP_OPEN reads.
+type mockParallelDownloaderServer struct {
+	content []byte
+}
+
+func (m *mockParallelDownloaderServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Query().Get("op") {
+	case OP_GETFILESTATUS:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"FileStatus": map[string]interface{}{"type": "FILE", "length": int64(len(m.content))},
+		})
+
+	case OP_GETFILECHECKSUM:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"FileChecksum": map[string]interface{}{"bytes": fmt.Sprintf("%d", len(m.content))},
+		})
+
+	case OP_OPEN:
+		var offset, length int64
+		fmt.Sscanf(r.URL.Query().Get("offset"), "%d", &offset)
+		fmt.Sscanf(r.URL.Query().Get("length"), "%d", &length)
+		end := offset + length
+		if end > int64(len(m.content)) {
+			end = int64(len(m.content))
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(m.content[offset:end])
+
+	default:
+		http.Error(w, "unsupported op", http.StatusNotImplemented)
+	}
+}
+
+// TestParallelDownloaderDownload confirms Download splits a file across
+// ranges and reassembles it correctly at the destination.
+func TestParallelDownloaderDownload(t *testing.T) {
+	want := make([]byte, 30)
+	for i := range want {
+		want[i] = byte('a' + i%26)
+	}
+	server := httptest.NewServer(&mockParallelDownloaderServer{content: want})
+	defer server.Close()
+
+	fs, err := NewFileSystem(Configuration{
+		Addr:    mustHost(t, server.URL),
+		Retries: func() []time.Duration { return nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dest, err := os.CreateTemp(t.TempDir(), "parallel-downloader-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dest.Close()
+
+	d := fs.NewParallelDownloader(WithRangeSize(8), WithParallelism(3))
+	if err := d.Download(&Path{Name: "/data"}, dest, ""); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+
+	got, err := os.ReadFile(dest.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("downloaded content = %q, want %q", got, want)
+	}
+}